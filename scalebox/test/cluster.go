@@ -0,0 +1,174 @@
+package main
+
+import (
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "os"
+    "path/filepath"
+    "regexp"
+    "sort"
+    "strings"
+)
+
+var (
+    reTimestamp = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:\.\d+)?(?:Z|[+-]\d{2}:?\d{2})?|\b\d{2}:\d{2}:\d{2}\b`)
+    reLineNum   = regexp.MustCompile(`\bline \d+\b|:\d+:\d+\b|:\d+\b`)
+)
+
+// fingerprintShape strips the noise that makes two occurrences of the
+// "same" failure look different byte-for-byte - timestamps, UUIDs,
+// sbx_* and explicit sandbox IDs, and source line numbers - leaving
+// only the shape of the traceback/report that a human would call "the
+// same failure".
+func fingerprintShape(report string) string {
+    s := report
+    s = reUUID.ReplaceAllString(s, "<id>")
+    s = reSbxID.ReplaceAllString(s, "<id>")
+    for _, re := range reSandboxExplicit {
+        s = re.ReplaceAllString(s, "<sandbox>")
+    }
+    s = reTimestamp.ReplaceAllString(s, "<time>")
+    s = reLineNum.ReplaceAllString(s, "<line>")
+    return s
+}
+
+// nonEmptyLines returns up to n trailing non-empty, trimmed lines of s.
+func nonEmptyLines(s string, n int) []string {
+    var lines []string
+    for _, l := range strings.Split(s, "\n") {
+        l = strings.TrimSpace(l)
+        if l != "" {
+            lines = append(lines, l)
+        }
+    }
+    if len(lines) > n {
+        lines = lines[len(lines)-n:]
+    }
+    return lines
+}
+
+// fingerprint hashes the shape of the last few non-empty lines of a
+// failure report into a short, stable identifier, and returns a 1-line
+// synopsis for display alongside it.
+func fingerprint(report string) (fp string, synopsis string) {
+    shape := fingerprintShape(report)
+    lines := nonEmptyLines(shape, 5)
+    sum := sha256.Sum256([]byte(strings.Join(lines, "\n")))
+    fp = hex.EncodeToString(sum[:])[:12]
+
+    synopsis = "(empty report)"
+    if len(lines) > 0 {
+        synopsis = truncateRunes(lines[len(lines)-1], 100)
+    }
+    return fp, synopsis
+}
+
+// truncateRunes shortens s to at most n runes, appending "..." if it was
+// cut short. Slicing by rune (not byte) avoids splitting a multi-byte
+// UTF-8 character, which matters here since reports are Chinese-language.
+func truncateRunes(s string, n int) string {
+    r := []rune(s)
+    if len(r) <= n {
+        return s
+    }
+    return string(r[:n]) + "..."
+}
+
+// clusterEntry is one run that landed in a cluster, persisted to
+// clusters.json so a fingerprint can be traced back to sandbox IDs for
+// server-side log lookup.
+type clusterEntry struct {
+    RunID      int     `json:"run_id"`
+    SandboxID  string  `json:"sandbox_id,omitempty"`
+    DurationSec float64 `json:"duration_seconds"`
+}
+
+type failureCluster struct {
+    Fingerprint     string
+    Synopsis        string
+    FirstSeenRun    int
+    SampleSandboxID string
+    Entries         []clusterEntry
+}
+
+// Clusterer groups FAIL/HANG results by normalized fingerprint so a
+// 1000-run soak test collapses hundreds of identical failures into a
+// handful of actionable buckets.
+type Clusterer struct {
+    clusters map[string]*failureCluster
+    order    []string // fingerprints in first-seen order
+}
+
+func NewClusterer() *Clusterer {
+    return &Clusterer{clusters: make(map[string]*failureCluster)}
+}
+
+// Add files a failed result into its cluster, creating one if this is
+// the first time this fingerprint has been seen. Passing results are
+// ignored.
+func (c *Clusterer) Add(r runResult) {
+    if r.pass {
+        return
+    }
+    fp, synopsis := fingerprint(r.report)
+    cl, ok := c.clusters[fp]
+    if !ok {
+        cl = &failureCluster{Fingerprint: fp, Synopsis: synopsis, FirstSeenRun: r.id}
+        c.clusters[fp] = cl
+        c.order = append(c.order, fp)
+    }
+    if cl.SampleSandboxID == "" {
+        cl.SampleSandboxID = r.sandboxID
+    }
+    if r.id < cl.FirstSeenRun {
+        cl.FirstSeenRun = r.id
+    }
+    cl.Entries = append(cl.Entries, clusterEntry{RunID: r.id, SandboxID: r.sandboxID, DurationSec: r.duration.Seconds()})
+}
+
+// ranked returns clusters sorted by descending entry count, ties broken
+// by first-seen order.
+func (c *Clusterer) ranked() []*failureCluster {
+    out := make([]*failureCluster, 0, len(c.clusters))
+    for _, fp := range c.order {
+        out = append(out, c.clusters[fp])
+    }
+    sort.SliceStable(out, func(i, j int) bool {
+        return len(out[i].Entries) > len(out[j].Entries)
+    })
+    return out
+}
+
+// PrintTable writes a ranked `count | first-seen run | sample sandbox_id |
+// fingerprint | synopsis` table to w.
+func (c *Clusterer) PrintTable(w *os.File) {
+    ranked := c.ranked()
+    if len(ranked) == 0 {
+        return
+    }
+    fmt.Fprintln(w, strings.Repeat("-", 64))
+    fmt.Fprintf(w, "%-6s %-12s %-14s %-14s %s\n", "count", "first-seen", "sandbox_id", "fingerprint", "synopsis")
+    for _, cl := range ranked {
+        sandboxID := cl.SampleSandboxID
+        if sandboxID == "" {
+            sandboxID = "-"
+        }
+        fmt.Fprintf(w, "%-6d run-%03d    %-14s %-14s %s\n", len(cl.Entries), cl.FirstSeenRun, sandboxID, cl.Fingerprint, cl.Synopsis)
+    }
+}
+
+// WriteJSON persists fingerprint -> [{run_id, sandbox_id, duration}] to
+// outputDir/clusters.json.
+func (c *Clusterer) WriteJSON(outputDir string) error {
+    out := make(map[string][]clusterEntry, len(c.clusters))
+    for fp, cl := range c.clusters {
+        out[fp] = cl.Entries
+    }
+    data, err := json.MarshalIndent(out, "", "  ")
+    if err != nil {
+        return fmt.Errorf("marshal clusters.json: %w", err)
+    }
+    return os.WriteFile(filepath.Join(outputDir, "clusters.json"), data, 0o644)
+}
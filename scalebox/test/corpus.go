@@ -0,0 +1,227 @@
+package main
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "math/rand"
+    "os"
+    "path/filepath"
+    "sync"
+    "time"
+)
+
+// Input is one parameterization of the Python test invocation: a
+// timeout, a region, a sandbox template ID, and an inline code snippet
+// payload. Zero values mean "let the test use its own default" so that
+// defaultInput() reproduces today's fixed invocation exactly.
+type Input struct {
+    TimeoutSec  int    `json:"timeout_sec,omitempty"`
+    Region      string `json:"region,omitempty"`
+    TemplateID  string `json:"template_id,omitempty"`
+    CodeSnippet string `json:"code_snippet,omitempty"`
+}
+
+// defaultInput is the zero-value case: no env overrides, identical to
+// the test invocation before fuzzing existed.
+func defaultInput() Input { return Input{} }
+
+// applyEnv layers the Input's fields on top of the base environment as
+// SCALEBOX_* variables the Python test reads, leaving base untouched
+// when a field is at its zero value.
+func (in Input) applyEnv(base []string) []string {
+    env := append([]string{}, base...)
+    if in.TimeoutSec != 0 {
+        env = append(env, fmt.Sprintf("SCALEBOX_TEST_TIMEOUT=%d", in.TimeoutSec))
+    }
+    if in.Region != "" {
+        env = append(env, "SCALEBOX_REGION="+in.Region)
+    }
+    if in.TemplateID != "" {
+        env = append(env, "SCALEBOX_TEMPLATE_ID="+in.TemplateID)
+    }
+    if in.CodeSnippet != "" {
+        env = append(env, "SCALEBOX_CODE_SNIPPET="+in.CodeSnippet)
+    }
+    return env
+}
+
+// hash returns a stable short fingerprint used as the testdata/fuzz/<hash>
+// directory name for a persisted failing case.
+func (in Input) hash() string {
+    data, _ := json.Marshal(in)
+    sum := sha256.Sum256(data)
+    return hex.EncodeToString(sum[:])[:12]
+}
+
+var fuzzRegions = []string{"us-east-1", "us-west-2", "eu-central-1", "ap-southeast-1"}
+
+// Corpus generates Input values to drive fuzz runs and records the
+// outcome of each one. Next is called once per run; Report lets the
+// corpus react to pass/fail (e.g. to trigger minimization).
+type Corpus interface {
+    Next() Input
+    Report(in Input, res runResult)
+}
+
+// seedCorpus generates Inputs by mutating a pool of seed code snippets
+// read from -fuzz-seed-dir with randomized timeouts and regions. It never
+// sets TemplateID, so every generated Input runs with the test's default
+// sandbox template. On failure it delta-debugs the input down to a minimal
+// reproducer and persists it under testdata/fuzz/<hash> for future runs
+// to replay first.
+type seedCorpus struct {
+    seeds             []string // snippet contents read from the seed dir
+    minimize          bool
+    perTestTimeoutSec int
+    hangGrace         time.Duration
+
+    mu  sync.Mutex // guards rng, which is called concurrently by every worker
+    rng *rand.Rand
+}
+
+// NewSeedCorpus reads every file in seedDir as a candidate code snippet.
+// A missing or empty seedDir falls back to a single built-in snippet so
+// -fuzz still works without a prepared corpus.
+func NewSeedCorpus(seedDir string, minimize bool, perTestTimeoutSec int, hangGrace time.Duration, rng *rand.Rand) *seedCorpus {
+    c := &seedCorpus{minimize: minimize, perTestTimeoutSec: perTestTimeoutSec, hangGrace: hangGrace, rng: rng}
+    if seedDir != "" {
+        entries, err := os.ReadDir(seedDir)
+        if err == nil {
+            for _, e := range entries {
+                if e.IsDir() {
+                    continue
+                }
+                data, err := os.ReadFile(filepath.Join(seedDir, e.Name()))
+                if err == nil {
+                    c.seeds = append(c.seeds, string(data))
+                }
+            }
+        }
+    }
+    if len(c.seeds) == 0 {
+        c.seeds = []string{"print('hello from scalebox fuzz corpus')"}
+    }
+    return c
+}
+
+// Next is called concurrently by every worker goroutine at up to
+// -max-concurrency, and rand.Rand is not safe for concurrent use, so all
+// access to c.rng is serialized under c.mu.
+func (c *seedCorpus) Next() Input {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return Input{
+        TimeoutSec:  []int{30, 60, 120, 300}[c.rng.Intn(4)],
+        Region:      fuzzRegions[c.rng.Intn(len(fuzzRegions))],
+        CodeSnippet: c.seeds[c.rng.Intn(len(c.seeds))],
+    }
+}
+
+func (c *seedCorpus) Report(in Input, res runResult) {
+    if res.pass || !c.minimize {
+        return
+    }
+    minimal := minimizeInput(in, func(candidate Input) bool {
+        ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.perTestTimeoutSec)*time.Second)
+        defer cancel()
+        cfg := runConfig{hangGrace: c.hangGrace}
+        return !runOnceWithInput(ctx, res.id, cfg, candidate).pass
+    })
+    persistFailingCase(minimal)
+}
+
+// minimizeInput delta-debugs a failing Input back towards defaultInput():
+// for each non-default field, in turn, try resetting it and keep the
+// reset if the case still fails. Each field is tried once, in the order
+// below, so the result still reproduces the failure but is not guaranteed
+// to be the smallest such input.
+func minimizeInput(in Input, stillFails func(Input) bool) Input {
+    def := defaultInput()
+    candidate := in
+
+    tryReset := func(reset func(*Input)) {
+        trial := candidate
+        reset(&trial)
+        if trial == candidate {
+            return
+        }
+        if stillFails(trial) {
+            candidate = trial
+        }
+    }
+
+    tryReset(func(i *Input) { i.TimeoutSec = def.TimeoutSec })
+    tryReset(func(i *Input) { i.Region = def.Region })
+    tryReset(func(i *Input) { i.TemplateID = def.TemplateID })
+    tryReset(func(i *Input) { i.CodeSnippet = def.CodeSnippet })
+
+    return candidate
+}
+
+// replayCorpus serves previously persisted fuzz/<hash>/input.json cases
+// first, then falls back to another Corpus once the replay queue is
+// drained. This is what makes known regressions get re-checked before
+// any newly generated input on the next -fuzz run.
+type replayCorpus struct {
+    mu       sync.Mutex // guards next, read/written by every worker goroutine
+    replay   []Input
+    next     int
+    fallback Corpus
+}
+
+// NewReplayCorpus loads every testdata/fuzz/<hash>/input.json found on
+// disk (if any) ahead of fallback.
+func NewReplayCorpus(fallback Corpus) *replayCorpus {
+    var replay []Input
+    entries, err := os.ReadDir(filepath.Join("testdata", "fuzz"))
+    if err == nil {
+        for _, e := range entries {
+            if !e.IsDir() {
+                continue
+            }
+            data, err := os.ReadFile(filepath.Join("testdata", "fuzz", e.Name(), "input.json"))
+            if err != nil {
+                continue
+            }
+            var in Input
+            if json.Unmarshal(data, &in) == nil {
+                replay = append(replay, in)
+            }
+        }
+    }
+    return &replayCorpus{replay: replay, fallback: fallback}
+}
+
+func (c *replayCorpus) Next() Input {
+    c.mu.Lock()
+    if c.next < len(c.replay) {
+        in := c.replay[c.next]
+        c.next++
+        c.mu.Unlock()
+        return in
+    }
+    c.mu.Unlock()
+    return c.fallback.Next()
+}
+
+func (c *replayCorpus) Report(in Input, res runResult) {
+    c.fallback.Report(in, res)
+}
+
+// persistFailingCase writes a minimized failing Input to
+// testdata/fuzz/<hash>/input.json so a later -fuzz run can replay it
+// first via NewReplayCorpus.
+func persistFailingCase(in Input) {
+    dir := filepath.Join("testdata", "fuzz", in.hash())
+    if err := os.MkdirAll(dir, 0o755); err != nil {
+        fmt.Fprintf(os.Stderr, "warning: failed to persist fuzz case: %v\n", err)
+        return
+    }
+    data, _ := json.MarshalIndent(in, "", "  ")
+    if err := os.WriteFile(filepath.Join(dir, "input.json"), data, 0o644); err != nil {
+        fmt.Fprintf(os.Stderr, "warning: failed to persist fuzz case: %v\n", err)
+    }
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+    "os/exec"
+    "syscall"
+    "time"
+)
+
+// runConfig carries the per-invocation settings that are global to the
+// whole run (not per-Input, unlike fuzz fields): where to write
+// artifacts, and how to handle a run that hits its timeout.
+type runConfig struct {
+    outputDir          string
+    hangGrace          time.Duration
+    enableFaulthandler bool
+}
+
+// setpgid puts the child in its own process group so a hang escalation
+// can signal it and everything it spawned, not just the immediate PID.
+func setpgid(cmd *exec.Cmd) {
+    cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// escalate runs the hang-handling sequence for a child that has not
+// exited by its deadline: SIGQUIT first (to make a faulthandler-enabled
+// Python dump every thread's stack to stderr), then wait hangGrace for
+// it to exit on its own, then SIGTERM, then SIGKILL. It returns once the
+// process group has actually exited or been killed.
+//
+// done is closed by the caller's own Wait() goroutine once cmd.Process
+// has exited, so escalate stops waiting the moment that happens instead
+// of always sleeping the full grace period.
+func escalate(cmd *exec.Cmd, hangGrace time.Duration, done <-chan struct{}) {
+    pgid := cmd.Process.Pid
+
+    signalGroup(pgid, syscall.SIGQUIT)
+    if waitOrGrace(done, hangGrace) {
+        return
+    }
+
+    signalGroup(pgid, syscall.SIGTERM)
+    if waitOrGrace(done, hangGrace) {
+        return
+    }
+
+    signalGroup(pgid, syscall.SIGKILL)
+}
+
+// signalGroup best-effort delivers sig to the process group led by pgid.
+// Errors are ignored: the group may already be gone, which is the
+// success case we're aiming for anyway.
+func signalGroup(pgid int, sig syscall.Signal) {
+    _ = syscall.Kill(-pgid, sig)
+}
+
+// runStatus labels a result for the human-readable summary, distinguishing
+// a genuine hang (ran past its deadline) from an ordinary non-zero-exit
+// failure.
+func runStatus(r runResult) string {
+    switch {
+    case r.pass:
+        return "PASS"
+    case r.timedOut:
+        return "HANG"
+    default:
+        return "FAIL"
+    }
+}
+
+// waitOrGrace blocks until done fires or grace elapses, reporting which.
+func waitOrGrace(done <-chan struct{}, grace time.Duration) bool {
+    select {
+    case <-done:
+        return true
+    case <-time.After(grace):
+        return false
+    }
+}
@@ -6,11 +6,13 @@ import (
     "context"
     "flag"
     "fmt"
+    "math/rand"
     "os"
     "os/exec"
     "path/filepath"
     "regexp"
     "runtime"
+    "sort"
     "strings"
     "sync"
     "time"
@@ -41,10 +43,15 @@ var (
 )
 
 type runResult struct {
-    id       int
-    duration time.Duration
-    pass     bool
-    report   string
+    id          int
+    duration    time.Duration
+    pass        bool
+    report      string
+    exitCode    int
+    timedOut    bool
+    sandboxID   string
+    stdoutBytes int
+    stderrBytes int
 }
 
 func extractFinalReport(text string) string {
@@ -163,29 +170,86 @@ func analyzeReport(stdoutText, stderrText string, exitCode int) (pass bool, repo
     return
 }
 
-func runOnce(ctx context.Context, id int) runResult {
+func runOnce(ctx context.Context, id int, cfg runConfig) runResult {
+    return runOnceWithInput(ctx, id, cfg, defaultInput())
+}
+
+// runOnceWithInput is runOnce parameterized by a fuzz Input: its fields
+// are threaded through to the Python test as environment variables, so
+// the same binary invocation can be driven by either the fixed default
+// case or a generated/minimized fuzz case.
+//
+// Unlike exec.CommandContext's default behavior of SIGKILL-ing the child
+// the instant ctx expires, a deadline here triggers the hang escalation
+// sequence (SIGQUIT, grace, SIGTERM, grace, SIGKILL) so a Python
+// faulthandler dump of every thread's stack has a chance to reach
+// stderr before the process is actually removed.
+func runOnceWithInput(ctx context.Context, id int, cfg runConfig, input Input) runResult {
     start := time.Now()
 
+    args := []string{testFile}
+    env := input.applyEnv(os.Environ())
+    if cfg.enableFaulthandler {
+        args = []string{"-X", "faulthandler", testFile}
+        env = append(env, "PYTHONFAULTHANDLER=1")
+    }
+
     // Use the same Python that runs in PATH
-    cmd := exec.CommandContext(ctx, "python3", testFile)
+    cmd := exec.Command("python3", args...)
     cmd.Dir = repoRoot
+    cmd.Env = env
+    setpgid(cmd)
 
     var stdoutBuf, stderrBuf bytes.Buffer
     cmd.Stdout = &stdoutBuf
     cmd.Stderr = &stderrBuf
 
-    _ = cmd.Run()
+    timedOut := false
+    if err := cmd.Start(); err != nil {
+        stderrBuf.WriteString(err.Error())
+    } else {
+        done := make(chan struct{})
+        go func() {
+            _ = cmd.Wait()
+            close(done)
+        }()
+
+        select {
+        case <-done:
+        case <-ctx.Done():
+            timedOut = true
+            escalate(cmd, cfg.hangGrace, done)
+            <-done
+        }
+    }
 
     duration := time.Since(start)
     stdoutText := stdoutBuf.String()
     stderrText := stderrBuf.String()
-    pass, report := analyzeReport(stdoutText, stderrText, cmd.ProcessState.ExitCode())
+    exitCode := -1
+    if cmd.ProcessState != nil {
+        exitCode = cmd.ProcessState.ExitCode()
+    }
+    pass, report := analyzeReport(stdoutText, stderrText, exitCode)
+    if timedOut {
+        pass = false
+        report = "HANG: run exceeded its deadline\n\n" + extractFinalReport(stdoutText+"\n"+stderrText)
+    }
+
+    if cfg.outputDir != "" {
+        writeRunArtifacts(cfg.outputDir, id, stdoutText, stderrText)
+    }
 
     return runResult{
-        id:       id,
-        duration: duration,
-        pass:     pass,
-        report:   report,
+        id:          id,
+        duration:    duration,
+        pass:        pass,
+        report:      report,
+        exitCode:    exitCode,
+        timedOut:    timedOut,
+        sandboxID:   extractSandboxID(report + "\n" + stdoutText + "\n" + stderrText),
+        stdoutBytes: len(stdoutText),
+        stderrBytes: len(stderrText),
     }
 }
 
@@ -194,15 +258,73 @@ func main() {
     runtime.GOMAXPROCS(runtime.NumCPU())
 
     var (
-        concurrency int
+        minConcurrency    int
+        maxConcurrency    int
+        rampWindow        time.Duration
+        failureThreshold  float64
+        totalRuns         int
         perTestTimeoutSec int
+        outputFormat      string
+        outputDir         string
+        fuzz              bool
+        fuzzSeedDir       string
+        fuzzMinimize      bool
+        hangGrace         time.Duration
+        enableFaulthandler bool
+        progress          string
+        metricsAddr       string
     )
-    flag.IntVar(&concurrency, "concurrency", 1000, "number of concurrent runs")
+    flag.IntVar(&minConcurrency, "min-concurrency", 10, "starting (and floor) number of concurrent runs")
+    flag.IntVar(&maxConcurrency, "max-concurrency", 1000, "ceiling on concurrent runs the ramp will not exceed")
+    flag.DurationVar(&rampWindow, "ramp-window", 30*time.Second, "how often the scheduler re-evaluates concurrency")
+    flag.Float64Var(&failureThreshold, "failure-threshold", 0.1, "recent failure rate (0-1) above which concurrency backs off")
+    flag.IntVar(&totalRuns, "total-runs", 1000, "total number of test invocations to perform")
     flag.IntVar(&perTestTimeoutSec, "timeout", 600, "per test timeout seconds")
+    flag.StringVar(&outputFormat, "output-format", "text", "result output format: text, json, junit")
+    flag.StringVar(&outputDir, "output-dir", "", "directory to write per-run artifacts and results.json/results.xml (required for json/junit)")
+    flag.BoolVar(&fuzz, "fuzz", false, "drive each run with a generated Input (timeout/region/snippet; template is left at its default) instead of the fixed invocation")
+    flag.StringVar(&fuzzSeedDir, "fuzz-seed-dir", "", "directory of seed code snippets to mutate when -fuzz is set")
+    flag.BoolVar(&fuzzMinimize, "fuzz-minimize", true, "delta-debug failing fuzz inputs back to a minimal reproducer and persist it under testdata/fuzz")
+    flag.DurationVar(&hangGrace, "hang-grace", 10*time.Second, "grace period after each hang-escalation signal (SIGQUIT, then SIGTERM) before escalating further")
+    flag.BoolVar(&enableFaulthandler, "enable-faulthandler", false, "set PYTHONFAULTHANDLER=1 and pass -X faulthandler so a hang's SIGQUIT dumps all Python thread stacks")
+    flag.StringVar(&progress, "progress", "none", "progress reporting: none, tty (live dashboard), metrics (Prometheus /metrics endpoint)")
+    flag.StringVar(&metricsAddr, "metrics-addr", ":9090", "listen address for -progress metrics")
     flag.Parse()
 
-    if concurrency <= 0 {
-        fmt.Println("concurrency must be > 0")
+    if minConcurrency <= 0 || maxConcurrency <= 0 || totalRuns <= 0 {
+        fmt.Println("min-concurrency, max-concurrency, and total-runs must be > 0")
+        os.Exit(2)
+    }
+    if minConcurrency > maxConcurrency {
+        fmt.Println("min-concurrency must be <= max-concurrency")
+        os.Exit(2)
+    }
+    if failureThreshold < 0 || failureThreshold > 1 {
+        fmt.Println("failure-threshold must be between 0 and 1")
+        os.Exit(2)
+    }
+
+    switch outputFormat {
+    case "text", "json", "junit":
+    default:
+        fmt.Printf("invalid -output-format %q: must be text, json, or junit\n", outputFormat)
+        os.Exit(2)
+    }
+
+    if outputDir != "" {
+        if err := os.MkdirAll(outputDir, 0o755); err != nil {
+            fmt.Printf("failed to create -output-dir %s: %v\n", outputDir, err)
+            os.Exit(2)
+        }
+    } else if outputFormat != "text" {
+        fmt.Printf("-output-format %s requires -output-dir\n", outputFormat)
+        os.Exit(2)
+    }
+
+    switch progress {
+    case "none", "tty", "metrics":
+    default:
+        fmt.Printf("invalid -progress %q: must be none, tty, or metrics\n", progress)
         os.Exit(2)
     }
 
@@ -214,38 +336,64 @@ func main() {
 
     // Print basic info
     abs, _ := filepath.Abs(testFile)
-    fmt.Printf("Running %d concurrent tests against %s\n", concurrency, abs)
+    fmt.Printf("Running %d tests against %s (concurrency ramps %d..%d every %s)\n",
+        totalRuns, abs, minConcurrency, maxConcurrency, rampWindow)
 
-    // We'll launch N goroutines. Each has its own context with timeout.
     // Printing is serialized to avoid interleaving lines.
     outMu := &sync.Mutex{}
 
     ctx := context.Background()
     overallStart := time.Now()
-    var wg sync.WaitGroup
-    results := make(chan runResult, concurrency)
-
-    for i := 1; i <= concurrency; i++ {
-        wg.Add(1)
-        go func(id int) {
-            defer wg.Done()
-            c, cancel := context.WithTimeout(ctx, time.Duration(perTestTimeoutSec)*time.Second)
-            defer cancel()
-            res := runOnce(c, id)
-            results <- res
-        }(i)
-    }
-
-    // Close results when done
-    go func() {
-        wg.Wait()
-        close(results)
-    }()
+
+    runCfg := runConfig{
+        outputDir:          outputDir,
+        hangGrace:          hangGrace,
+        enableFaulthandler: enableFaulthandler,
+    }
+
+    var corpus Corpus
+    if fuzz {
+        rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+        corpus = NewReplayCorpus(NewSeedCorpus(fuzzSeedDir, fuzzMinimize, perTestTimeoutSec, hangGrace, rng))
+    }
+
+    sched := NewScheduler(schedulerConfig{
+        minConcurrency:    minConcurrency,
+        maxConcurrency:    maxConcurrency,
+        rampWindow:        rampWindow,
+        failureThreshold:  failureThreshold,
+        totalRuns:         totalRuns,
+        perTestTimeoutSec: perTestTimeoutSec,
+        runCfg:            runCfg,
+        corpus:            corpus,
+    })
+    results := sched.Run(ctx)
+
+    var metricsReg *metricsRegistry
+    if progress == "metrics" {
+        metricsReg = newMetricsRegistry(sched)
+        serveMetrics(metricsAddr, metricsReg)
+        fmt.Printf("Serving Prometheus metrics on %s/metrics\n", metricsAddr)
+    }
+
+    var ttyStop chan struct{}
+    var ttyDone chan struct{}
+    stats := newProgressStats(totalRuns)
+    if progress == "tty" {
+        ttyStop = make(chan struct{})
+        ttyDone = make(chan struct{})
+        tty := newTTYRenderer(os.Stdout, stats, sched)
+        go func() {
+            tty.run(300*time.Millisecond, ttyStop)
+            close(ttyDone)
+        }()
+    }
 
     // Consume results as they arrive, print progressively
     completed := 0
     passed := 0
     failed := 0
+    all := make([]runResult, 0, totalRuns)
 
     writer := bufio.NewWriter(os.Stdout)
     for r := range results {
@@ -255,26 +403,57 @@ func main() {
         } else {
             failed++
         }
-        outMu.Lock()
-        fmt.Fprintf(writer, "Run %03d: %s in %.2fs\n", r.id, ternary(r.pass, "PASS", "FAIL"), r.duration.Seconds())
-        if r.report != "" {
-            fmt.Fprintln(writer, r.report)
-            fmt.Fprintln(writer, strings.Repeat("-", 64))
+        all = append(all, r)
+        stats.record(r)
+        if metricsReg != nil {
+            metricsReg.record(r)
+        }
+        if progress == "none" || progress == "metrics" {
+            outMu.Lock()
+            fmt.Fprintf(writer, "Run %03d: %s in %.2fs\n", r.id, runStatus(r), r.duration.Seconds())
+            if r.report != "" {
+                fmt.Fprintln(writer, r.report)
+                fmt.Fprintln(writer, strings.Repeat("-", 64))
+            }
+            writer.Flush()
+            outMu.Unlock()
         }
-        writer.Flush()
-        outMu.Unlock()
+    }
+
+    if ttyStop != nil {
+        close(ttyStop)
+        <-ttyDone
     }
 
     totalTime := time.Since(overallStart).Seconds()
     fmt.Println(strings.Repeat("-", 64))
     fmt.Printf("Completed: %d, Passed: %d, Failed: %d, Total time: %.2fs\n", completed, passed, failed, totalTime)
-}
 
-func ternary[T any](cond bool, a, b T) T {
-    if cond {
-        return a
+    sort.Slice(all, func(i, j int) bool { return all[i].id < all[j].id })
+
+    clusterer := NewClusterer()
+    for _, r := range all {
+        clusterer.Add(r)
+    }
+    clusterer.PrintTable(os.Stdout)
+    if outputDir != "" {
+        if err := clusterer.WriteJSON(outputDir); err != nil {
+            fmt.Printf("failed to write clusters.json: %v\n", err)
+        }
+    }
+
+    switch outputFormat {
+    case "json":
+        if err := writeResultsJSON(outputDir, all, completed, passed, failed, totalTime); err != nil {
+            fmt.Printf("failed to write results.json: %v\n", err)
+            os.Exit(1)
+        }
+    case "junit":
+        if err := writeJUnitXML(outputDir, all, failed, totalTime); err != nil {
+            fmt.Printf("failed to write results.xml: %v\n", err)
+            os.Exit(1)
+        }
     }
-    return b
 }
 
 
@@ -0,0 +1,104 @@
+package main
+
+import (
+    "fmt"
+    "net/http"
+    "os"
+    "sync"
+    "sync/atomic"
+)
+
+// defaultDurationBuckets are the histogram bucket upper bounds (seconds)
+// for run_duration_seconds, chosen to span a quick unit test up through
+// the 600s default per-test timeout.
+var defaultDurationBuckets = []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+// metricsRegistry tracks the counters/histogram/gauge exposed on
+// -metrics-addr's /metrics endpoint, in the Prometheus text exposition
+// format, without depending on an external client library.
+type metricsRegistry struct {
+    passed int64 // atomic
+    failed int64 // atomic
+    hung   int64 // atomic
+
+    mu           sync.Mutex
+    bucketBounds []float64 // upper bound per bucket, ascending
+    bucketCounts []int64   // cumulative count of observations <= bound, parallel to bucketBounds
+    sum          float64
+    count        int64
+
+    sched *Scheduler
+}
+
+func newMetricsRegistry(sched *Scheduler) *metricsRegistry {
+    return &metricsRegistry{
+        bucketBounds: defaultDurationBuckets,
+        bucketCounts: make([]int64, len(defaultDurationBuckets)),
+        sched:        sched,
+    }
+}
+
+func (m *metricsRegistry) record(r runResult) {
+    switch {
+    case r.pass:
+        atomic.AddInt64(&m.passed, 1)
+    case r.timedOut:
+        atomic.AddInt64(&m.hung, 1)
+    default:
+        atomic.AddInt64(&m.failed, 1)
+    }
+
+    d := r.duration.Seconds()
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.sum += d
+    m.count++
+    for i, bound := range m.bucketBounds {
+        if d <= bound {
+            m.bucketCounts[i]++
+        }
+    }
+}
+
+// ServeHTTP renders the registry in Prometheus text exposition format.
+func (m *metricsRegistry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+    _, inFlight, _, _ := m.sched.Stats()
+
+    m.mu.Lock()
+    sum, count := m.sum, m.count
+    bounds := append([]float64{}, m.bucketBounds...)
+    counts := append([]int64{}, m.bucketCounts...)
+    m.mu.Unlock()
+
+    fmt.Fprintln(w, "# HELP runs_total Total test runs by result.")
+    fmt.Fprintln(w, "# TYPE runs_total counter")
+    fmt.Fprintf(w, "runs_total{result=\"pass\"} %d\n", atomic.LoadInt64(&m.passed))
+    fmt.Fprintf(w, "runs_total{result=\"fail\"} %d\n", atomic.LoadInt64(&m.failed))
+    fmt.Fprintf(w, "runs_total{result=\"hang\"} %d\n", atomic.LoadInt64(&m.hung))
+
+    fmt.Fprintln(w, "# HELP runs_in_flight Runs currently executing.")
+    fmt.Fprintln(w, "# TYPE runs_in_flight gauge")
+    fmt.Fprintf(w, "runs_in_flight %d\n", inFlight)
+
+    fmt.Fprintln(w, "# HELP run_duration_seconds Per-run wall clock duration.")
+    fmt.Fprintln(w, "# TYPE run_duration_seconds histogram")
+    for i, bound := range bounds {
+        fmt.Fprintf(w, "run_duration_seconds_bucket{le=\"%g\"} %d\n", bound, counts[i])
+    }
+    fmt.Fprintf(w, "run_duration_seconds_bucket{le=\"+Inf\"} %d\n", count)
+    fmt.Fprintf(w, "run_duration_seconds_sum %f\n", sum)
+    fmt.Fprintf(w, "run_duration_seconds_count %d\n", count)
+}
+
+// serveMetrics starts the /metrics HTTP server in the background. A
+// listen failure (e.g. the address is already in use) is logged but
+// does not abort the run, since metrics are observability, not correctness.
+func serveMetrics(addr string, reg *metricsRegistry) {
+    mux := http.NewServeMux()
+    mux.Handle("/metrics", reg)
+    go func() {
+        if err := http.ListenAndServe(addr, mux); err != nil {
+            fmt.Fprintf(os.Stderr, "metrics server error: %v\n", err)
+        }
+    }()
+}
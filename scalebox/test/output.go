@@ -0,0 +1,135 @@
+package main
+
+import (
+    "encoding/json"
+    "encoding/xml"
+    "fmt"
+    "os"
+    "path/filepath"
+)
+
+// writeRunArtifacts persists the raw stdout/stderr of a single run under
+// outputDir as run-NNN.stdout / run-NNN.stderr, so failures from a large
+// soak test can be inspected after the fact without re-running anything.
+func writeRunArtifacts(outputDir string, id int, stdoutText, stderrText string) {
+    stdoutPath := filepath.Join(outputDir, fmt.Sprintf("run-%03d.stdout", id))
+    stderrPath := filepath.Join(outputDir, fmt.Sprintf("run-%03d.stderr", id))
+    if err := os.WriteFile(stdoutPath, []byte(stdoutText), 0o644); err != nil {
+        fmt.Fprintf(os.Stderr, "warning: failed to write %s: %v\n", stdoutPath, err)
+    }
+    if err := os.WriteFile(stderrPath, []byte(stderrText), 0o644); err != nil {
+        fmt.Fprintf(os.Stderr, "warning: failed to write %s: %v\n", stderrPath, err)
+    }
+}
+
+// jsonRunResult is the per-run shape persisted to results.json. It mirrors
+// runResult but with stable, exported-ish field names so downstream tooling
+// (CI dashboards, go test -json style consumers) has a fixed contract.
+type jsonRunResult struct {
+    ID          int     `json:"id"`
+    Pass        bool    `json:"pass"`
+    TimedOut    bool    `json:"timed_out"`
+    ExitCode    int     `json:"exit_code"`
+    DurationSec float64 `json:"duration_seconds"`
+    StdoutBytes int     `json:"stdout_bytes"`
+    StderrBytes int     `json:"stderr_bytes"`
+    SandboxID   string  `json:"sandbox_id,omitempty"`
+    Report      string  `json:"report,omitempty"`
+}
+
+type jsonSummary struct {
+    Completed     int             `json:"completed"`
+    Passed        int             `json:"passed"`
+    Failed        int             `json:"failed"`
+    TotalTimeSec  float64         `json:"total_time_seconds"`
+    Runs          []jsonRunResult `json:"runs"`
+}
+
+func toJSONRunResult(r runResult) jsonRunResult {
+    return jsonRunResult{
+        ID:          r.id,
+        Pass:        r.pass,
+        TimedOut:    r.timedOut,
+        ExitCode:    r.exitCode,
+        DurationSec: r.duration.Seconds(),
+        StdoutBytes: r.stdoutBytes,
+        StderrBytes: r.stderrBytes,
+        SandboxID:   r.sandboxID,
+        Report:      r.report,
+    }
+}
+
+// writeResultsJSON writes a single combined results.json describing every
+// run, for CI systems that want to consume outcomes programmatically
+// instead of scraping the human-readable summary.
+func writeResultsJSON(outputDir string, results []runResult, completed, passed, failed int, totalTime float64) error {
+    summary := jsonSummary{
+        Completed:    completed,
+        Passed:       passed,
+        Failed:       failed,
+        TotalTimeSec: totalTime,
+    }
+    for _, r := range results {
+        summary.Runs = append(summary.Runs, toJSONRunResult(r))
+    }
+    data, err := json.MarshalIndent(summary, "", "  ")
+    if err != nil {
+        return fmt.Errorf("marshal results.json: %w", err)
+    }
+    return os.WriteFile(filepath.Join(outputDir, "results.json"), data, 0o644)
+}
+
+// JUnit XML schema, kept minimal (just what CI consumers of `go test -json`
+// style tooling typically need: one testcase per run, with a failure
+// element carrying the captured report).
+type junitTestsuite struct {
+    XMLName   xml.Name        `xml:"testsuite"`
+    Name      string          `xml:"name,attr"`
+    Tests     int             `xml:"tests,attr"`
+    Failures  int             `xml:"failures,attr"`
+    Time      float64         `xml:"time,attr"`
+    TestCases []junitTestcase `xml:"testcase"`
+}
+
+type junitTestcase struct {
+    Name    string        `xml:"name,attr"`
+    Time    float64       `xml:"time,attr"`
+    Failure *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+    Message string `xml:"message,attr"`
+    Text    string `xml:",chardata"`
+}
+
+// writeJUnitXML writes results.json's JUnit equivalent, letting CI render
+// pass/fail counts and per-run failure detail in the same way it already
+// does for `go test -json`.
+func writeJUnitXML(outputDir string, results []runResult, failed int, totalTime float64) error {
+    suite := junitTestsuite{
+        Name:     "scalebox-sync-comprehensive",
+        Tests:    len(results),
+        Failures: failed,
+        Time:     totalTime,
+    }
+    for _, r := range results {
+        tc := junitTestcase{
+            Name: fmt.Sprintf("run-%03d", r.id),
+            Time: r.duration.Seconds(),
+        }
+        if !r.pass {
+            message := "FAIL"
+            if r.timedOut {
+                message = "HANG"
+            }
+            tc.Failure = &junitFailure{Message: message, Text: r.report}
+        }
+        suite.TestCases = append(suite.TestCases, tc)
+    }
+    data, err := xml.MarshalIndent(suite, "", "  ")
+    if err != nil {
+        return fmt.Errorf("marshal junit xml: %w", err)
+    }
+    data = append([]byte(xml.Header), data...)
+    return os.WriteFile(filepath.Join(outputDir, "results.xml"), data, 0o644)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "sort"
+    "sync"
+    "time"
+)
+
+// progressStats accumulates the live state a -progress renderer needs:
+// pass/fail/hang counts, a bounded window of durations for percentiles,
+// a per-run status grid, and a rolling log of the most recent failures.
+// It is safe for concurrent use; the result consumer loop calls record
+// for every run, while a separate ticker reads a snapshot to render.
+type progressStats struct {
+    mu sync.Mutex
+
+    total     int
+    completed int
+    passed    int
+    failed    int
+    hung      int
+
+    durations []float64 // seconds, capped at maxDurationWindow, newest last
+    grid      []byte    // one byte per run id (1-indexed, grid[0] unused), '.' until a result lands
+    lastFails []string  // capped at maxRecentFails, newest last
+}
+
+const (
+    maxDurationWindow = 500
+    maxRecentFails    = 8
+)
+
+func newProgressStats(total int) *progressStats {
+    grid := make([]byte, total+1)
+    for i := range grid {
+        grid[i] = '.'
+    }
+    return &progressStats{total: total, grid: grid}
+}
+
+func (s *progressStats) record(r runResult) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.completed++
+    switch {
+    case r.pass:
+        s.passed++
+        if r.id < len(s.grid) {
+            s.grid[r.id] = 'P'
+        }
+    case r.timedOut:
+        s.hung++
+        if r.id < len(s.grid) {
+            s.grid[r.id] = 'H'
+        }
+    default:
+        s.failed++
+        if r.id < len(s.grid) {
+            s.grid[r.id] = 'F'
+        }
+    }
+
+    s.durations = append(s.durations, r.duration.Seconds())
+    if len(s.durations) > maxDurationWindow {
+        s.durations = s.durations[len(s.durations)-maxDurationWindow:]
+    }
+
+    if !r.pass {
+        synopsis := r.report
+        if nl := indexByte(synopsis, '\n'); nl >= 0 {
+            synopsis = synopsis[:nl]
+        }
+        entry := fmt.Sprintf("run-%03d: %s", r.id, synopsis)
+        s.lastFails = append(s.lastFails, entry)
+        if len(s.lastFails) > maxRecentFails {
+            s.lastFails = s.lastFails[len(s.lastFails)-maxRecentFails:]
+        }
+    }
+}
+
+func indexByte(s string, b byte) int {
+    for i := 0; i < len(s); i++ {
+        if s[i] == b {
+            return i
+        }
+    }
+    return -1
+}
+
+// snapshot returns copies of the fields the renderer needs, plus mean/p50/p95
+// of the duration window.
+func (s *progressStats) snapshot() (completed, passed, failed, hung int, grid []byte, lastFails []string, mean, p50, p95 float64) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    completed, passed, failed, hung = s.completed, s.passed, s.failed, s.hung
+    grid = append([]byte{}, s.grid...)
+    lastFails = append([]string{}, s.lastFails...)
+
+    if len(s.durations) == 0 {
+        return
+    }
+    sorted := append([]float64{}, s.durations...)
+    sort.Float64s(sorted)
+    var sum float64
+    for _, d := range sorted {
+        sum += d
+    }
+    mean = sum / float64(len(sorted))
+    p50 = percentile(sorted, 0.50)
+    p95 = percentile(sorted, 0.95)
+    return
+}
+
+func percentile(sorted []float64, p float64) float64 {
+    if len(sorted) == 0 {
+        return 0
+    }
+    idx := int(p * float64(len(sorted)-1))
+    return sorted[idx]
+}
+
+// ttyRenderer draws a live, in-place dashboard using plain ANSI cursor
+// control (no external TUI library): move-to-home, clear the whole screen,
+// then redraw every line. The number of lines per frame varies with the
+// recent-failure log; clearing the screen first keeps stale lines away.
+type ttyRenderer struct {
+    w       io.Writer
+    stats   *progressStats
+    sched   *Scheduler
+    started bool
+}
+
+func newTTYRenderer(w io.Writer, stats *progressStats, sched *Scheduler) *ttyRenderer {
+    return &ttyRenderer{w: w, stats: stats, sched: sched}
+}
+
+// run redraws the dashboard every interval until stop is closed.
+func (t *ttyRenderer) run(interval time.Duration, stop <-chan struct{}) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        t.render()
+        select {
+        case <-stop:
+            t.render()
+            return
+        case <-ticker.C:
+        }
+    }
+}
+
+func (t *ttyRenderer) render() {
+    completed, passed, failed, hung, grid, lastFails, mean, p50, p95 := t.stats.snapshot()
+    launched, inFlight, running, desired := t.sched.Stats()
+
+    if t.started {
+        fmt.Fprint(t.w, "\033[H\033[2J")
+    }
+    t.started = true
+
+    fmt.Fprintf(t.w, "scalebox soak run: %d/%d launched, %d running, %d/%d concurrency\n",
+        launched, t.stats.total, inFlight, running, desired)
+    fmt.Fprintf(t.w, "completed=%d passed=%d failed=%d hung=%d\n", completed, passed, failed, hung)
+    fmt.Fprintf(t.w, "duration mean=%.2fs p50=%.2fs p95=%.2fs\n", mean, p50, p95)
+    fmt.Fprintln(t.w, renderGrid(grid))
+    fmt.Fprintln(t.w, "recent failures:")
+    if len(lastFails) == 0 {
+        fmt.Fprintln(t.w, "  (none)")
+    }
+    for _, f := range lastFails {
+        fmt.Fprintf(t.w, "  %s\n", f)
+    }
+}
+
+// renderGrid draws one character per run (grid[0] is unused, ids are
+// 1-indexed), so a 1000-run soak test collapses to a glance-able block.
+func renderGrid(grid []byte) string {
+    if len(grid) <= 1 {
+        return ""
+    }
+    return "[" + string(grid[1:]) + "]"
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+    "context"
+    "sync"
+    "sync/atomic"
+    "time"
+)
+
+// slidingWindowSize bounds how many recent results the ramp decision looks
+// at. Large enough to smooth over a handful of flaky runs, small enough
+// that the scheduler reacts to a regression within a window or two.
+const slidingWindowSize = 50
+
+// schedulerConfig holds the tunables for the adaptive ramp.
+type schedulerConfig struct {
+    minConcurrency    int
+    maxConcurrency    int
+    rampWindow        time.Duration
+    failureThreshold  float64 // fraction of recent failures that triggers backoff
+    totalRuns         int
+    perTestTimeoutSec int
+    runCfg            runConfig
+    corpus            Corpus // nil unless -fuzz is set
+}
+
+// Scheduler runs totalRuns invocations of runOnce through a bounded worker
+// pool whose size is adjusted every rampWindow: it doubles while the
+// recent pass-rate holds above (1 - failureThreshold) and duration stays
+// near baseline, and backs off multiplicatively the moment either signal
+// degrades. This avoids firing all N processes at once, which thrashes
+// the box and skews results, similar to how testing.B tunes b.N.
+type Scheduler struct {
+    cfg schedulerConfig
+
+    jobs    chan int
+    results chan runResult
+    stop    chan struct{}
+
+    // poolMu serializes every mutation of running together with the
+    // decision to close stop/results, so a rampLoop tick that grows the
+    // pool can never race the moment the last worker exits: spawnWorkers
+    // checks closed before adding, and the worker that drives running to
+    // zero is the one (and only) goroutine that closes the channels.
+    poolMu  sync.Mutex
+    running int  // live worker goroutines, guarded by poolMu
+    closed  bool // whether stop/results have already been closed
+
+    desired  int32 // atomic: current target concurrency
+    launched int32 // atomic: jobs dispatched to a worker so far
+    inFlight int32 // atomic: jobs currently executing
+
+    mu           sync.Mutex
+    window       []runResult // recent results, newest last, capped at slidingWindowSize
+    baselineEWMA float64     // seconds; 0 until first window closes
+}
+
+// NewScheduler builds a Scheduler ready to Run. minConcurrency is the
+// starting (and floor) worker count; maxConcurrency is the ceiling the
+// ramp will not exceed.
+func NewScheduler(cfg schedulerConfig) *Scheduler {
+    return &Scheduler{
+        cfg:     cfg,
+        jobs:    make(chan int, cfg.totalRuns),
+        results: make(chan runResult, cfg.totalRuns),
+        stop:    make(chan struct{}),
+        desired: int32(cfg.minConcurrency),
+    }
+}
+
+// Run feeds totalRuns jobs through the pool, starts the ramp monitor, and
+// returns the results channel, closing it once every job has completed
+// and every worker (including any spawned by a late ramp-up) has exited.
+func (s *Scheduler) Run(ctx context.Context) <-chan runResult {
+    for i := 1; i <= s.cfg.totalRuns; i++ {
+        s.jobs <- i
+    }
+    close(s.jobs)
+
+    s.spawnWorkers(ctx, s.cfg.minConcurrency)
+    go s.rampLoop(ctx)
+
+    return s.results
+}
+
+// spawnWorkers starts n additional long-lived workers pulling from jobs.
+// It refuses to spawn once the pool has already drained to zero and
+// closed stop/results - otherwise a ramp-up racing the final worker's
+// exit could resurrect workers after the channels are gone.
+func (s *Scheduler) spawnWorkers(ctx context.Context, n int) {
+    s.poolMu.Lock()
+    if s.closed {
+        s.poolMu.Unlock()
+        return
+    }
+    s.running += n
+    s.poolMu.Unlock()
+
+    for i := 0; i < n; i++ {
+        go s.worker(ctx)
+    }
+}
+
+// worker runs jobs until the queue is drained or it claims a shrink (pool
+// above desired), in which case it exits after finishing its current job
+// rather than mid-run.
+func (s *Scheduler) worker(ctx context.Context) {
+    for id := range s.jobs {
+        atomic.AddInt32(&s.launched, 1)
+        atomic.AddInt32(&s.inFlight, 1)
+        c, cancel := context.WithTimeout(ctx, time.Duration(s.cfg.perTestTimeoutSec)*time.Second)
+        var res runResult
+        if s.cfg.corpus != nil {
+            in := s.cfg.corpus.Next()
+            res = runOnceWithInput(c, id, s.cfg.runCfg, in)
+            s.cfg.corpus.Report(in, res)
+        } else {
+            res = runOnce(c, id, s.cfg.runCfg)
+        }
+        cancel()
+        atomic.AddInt32(&s.inFlight, -1)
+
+        s.record(res)
+        s.results <- res
+
+        if s.claimShrink() {
+            return
+        }
+    }
+    s.exit()
+}
+
+// claimShrink atomically checks whether the pool is still above desired
+// and, if so, claims this worker's exit by decrementing running right
+// here - under the same lock spawnWorkers and exit use - so concurrent
+// workers each see an up-to-date count and the pool settles exactly at
+// desired instead of overshooting on a sharp backoff.
+func (s *Scheduler) claimShrink() bool {
+    s.poolMu.Lock()
+    defer s.poolMu.Unlock()
+    if s.running <= int(atomic.LoadInt32(&s.desired)) {
+        return false
+    }
+    s.running--
+    s.maybeCloseLocked()
+    return true
+}
+
+// exit accounts for a worker whose job queue drained (as opposed to one
+// that claimed a shrink), decrementing running and closing stop/results
+// if this was the last one. Must be called with poolMu unlocked.
+func (s *Scheduler) exit() {
+    s.poolMu.Lock()
+    defer s.poolMu.Unlock()
+    s.running--
+    s.maybeCloseLocked()
+}
+
+// maybeCloseLocked closes stop/results exactly once, the moment running
+// reaches zero. Callers must hold poolMu.
+func (s *Scheduler) maybeCloseLocked() {
+    if s.running == 0 && !s.closed {
+        s.closed = true
+        close(s.stop)
+        close(s.results)
+    }
+}
+
+// Stats reports a point-in-time snapshot of scheduler activity for
+// progress reporting: how many jobs have been dispatched, how many are
+// currently executing, the live worker-pool size, and the current
+// target concurrency (running trails desired while the pool is still
+// growing, or leads it briefly while surplus workers shrink away).
+func (s *Scheduler) Stats() (launched, inFlight, running, desired int) {
+    s.poolMu.Lock()
+    running = s.running
+    s.poolMu.Unlock()
+    return int(atomic.LoadInt32(&s.launched)), int(atomic.LoadInt32(&s.inFlight)), running, int(atomic.LoadInt32(&s.desired))
+}
+
+// record appends a result to the sliding window used by the ramp loop.
+func (s *Scheduler) record(r runResult) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.window = append(s.window, r)
+    if len(s.window) > slidingWindowSize {
+        s.window = s.window[len(s.window)-slidingWindowSize:]
+    }
+}
+
+// passRateAndDuration summarizes the current sliding window.
+func (s *Scheduler) passRateAndDuration() (passRate float64, meanDuration float64, n int) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    n = len(s.window)
+    if n == 0 {
+        return 1, 0, 0
+    }
+    passed := 0
+    var total time.Duration
+    for _, r := range s.window {
+        if r.pass {
+            passed++
+        }
+        total += r.duration
+    }
+    return float64(passed) / float64(n), total.Seconds() / float64(n), n
+}
+
+// rampLoop adjusts desired concurrency every rampWindow based on recent
+// pass-rate and an EWMA of run duration: it doubles on sustained health,
+// and backs off by half the moment failures spike past failureThreshold
+// or mean duration grows beyond 2x the established baseline (a sign the
+// sandbox service itself is saturating, even while runs still pass).
+func (s *Scheduler) rampLoop(ctx context.Context) {
+    ticker := time.NewTicker(s.cfg.rampWindow)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-s.stop:
+            return
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+            passRate, meanDuration, n := s.passRateAndDuration()
+            if n == 0 {
+                continue
+            }
+
+            // Compare against the baseline as it stood *before* this
+            // window, then fold the window in - otherwise a spike drags
+            // the baseline toward itself and the saturation check never
+            // trips.
+            s.mu.Lock()
+            baseline := s.baselineEWMA
+            if baseline == 0 {
+                s.baselineEWMA = meanDuration
+            } else {
+                const alpha = 0.3
+                s.baselineEWMA = alpha*meanDuration + (1-alpha)*baseline
+            }
+            s.mu.Unlock()
+
+            saturated := baseline > 0 && meanDuration > 2*baseline
+            healthy := passRate >= 1-s.cfg.failureThreshold && !saturated
+
+            current := atomic.LoadInt32(&s.desired)
+            next := current
+            if healthy {
+                next = current * 2
+                if next > int32(s.cfg.maxConcurrency) {
+                    next = int32(s.cfg.maxConcurrency)
+                }
+            } else {
+                next = current / 2
+                if next < int32(s.cfg.minConcurrency) {
+                    next = int32(s.cfg.minConcurrency)
+                }
+            }
+            if next == current {
+                continue
+            }
+            atomic.StoreInt32(&s.desired, next)
+
+            s.poolMu.Lock()
+            grow := int(next) - s.running
+            s.poolMu.Unlock()
+            if grow > 0 {
+                s.spawnWorkers(ctx, grow)
+            }
+            // Shrinking is handled by workers claiming it via claimShrink;
+            // nothing to do here.
+        }
+    }
+}